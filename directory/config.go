@@ -0,0 +1,330 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigLoader resolves tagged config structs by layering sources in
+// increasing order of precedence:
+//
+//  1. the field's `default` tag
+//  2. an explicit config file set via WithConfigFile (YAML/TOML/JSON,
+//     detected by extension), keyed by the field's `env` tag
+//  3. the environment variable named in the field's `env` tag
+//  4. the field's `secret` tag, resolved against every SecretProvider
+//     registered with WithSecretProvider, in registration order
+//
+// A field with an `env` or `secret` tag that resolves to no value from
+// any source, and carries no `default`, is a validation error returned
+// from Load rather than a silently-applied zero value:
+//
+//	type RedisConfig struct {
+//		Host string `env:"KENGINE_REDIS_HOST" secret:"kv/data/kengine/redis#host" default:"localhost"`
+//	}
+type ConfigLoader struct {
+	configFile string
+	configData map[string]string
+	secrets    []SecretProvider
+}
+
+// ConfigLoaderOption configures a ConfigLoader returned by NewConfigLoader.
+type ConfigLoaderOption func(*ConfigLoader)
+
+// WithConfigFile layers a YAML, TOML, or JSON file (selected by its
+// extension) into the loader. The file must decode to a flat string map
+// keyed by the same names used in fields' `env` tags.
+func WithConfigFile(path string) ConfigLoaderOption {
+	return func(l *ConfigLoader) {
+		l.configFile = path
+	}
+}
+
+// WithSecretProvider registers a SecretProvider consulted for fields
+// carrying a `secret` tag. Providers are tried in registration order;
+// the first to resolve a value wins.
+func WithSecretProvider(p SecretProvider) ConfigLoaderOption {
+	return func(l *ConfigLoader) {
+		l.secrets = append(l.secrets, p)
+	}
+}
+
+// NewConfigLoader builds a ConfigLoader and eagerly reads its config
+// file, if one was supplied. A missing or malformed config file is
+// returned immediately rather than deferred to Load.
+func NewConfigLoader(opts ...ConfigLoaderOption) (*ConfigLoader, error) {
+	l := &ConfigLoader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.configFile == "" {
+		return l, nil
+	}
+	data, err := os.ReadFile(l.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("directory: read config file %q: %w", l.configFile, err)
+	}
+	decoded := map[string]string{}
+	switch ext := strings.ToLower(filepath.Ext(l.configFile)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &decoded)
+	case ".toml":
+		err = toml.Unmarshal(data, &decoded)
+	case ".json":
+		err = json.Unmarshal(data, &decoded)
+	default:
+		return nil, fmt.Errorf("directory: config file %q has unsupported extension %q", l.configFile, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("directory: parse config file %q: %w", l.configFile, err)
+	}
+	l.configData = decoded
+	return l, nil
+}
+
+// Load resolves target, a pointer to a struct whose fields carry `env`,
+// `secret`, and/or `default` tags, and reports every field that could
+// not be resolved as a single joined error.
+func (l *ConfigLoader) Load(ctx context.Context, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("directory: Load target must be a pointer to a struct, got %T", target)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	var errs []error
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		envKey, hasEnv := field.Tag.Lookup("env")
+		secretKey, hasSecret := field.Tag.Lookup("secret")
+		defaultVal, hasDefault := field.Tag.Lookup("default")
+		if !hasEnv && !hasSecret {
+			continue
+		}
+
+		resolved := defaultVal
+		found := hasDefault
+
+		if hasEnv && l.configData != nil {
+			if fileVal, ok := l.configData[envKey]; ok {
+				resolved, found = fileVal, true
+			}
+		}
+		if hasEnv {
+			if envVal, ok := os.LookupEnv(envKey); ok {
+				resolved, found = envVal, true
+			}
+		}
+		if hasSecret {
+			secretVal, ok, err := l.resolveSecret(ctx, secretKey)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", field.Name, err))
+				continue
+			}
+			if ok {
+				resolved, found = secretVal, true
+			}
+		}
+
+		if !found {
+			errs = append(errs, fmt.Errorf("%s: no value from config file, env %q, or secret %q, and no default", field.Name, envKey, secretKey))
+			continue
+		}
+		if err := setField(v.Field(i), resolved); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setField converts the resolved string value into fv according to its
+// kind. Only the scalar kinds actually used by directory's config
+// structs (string, int, bool) are supported.
+func setField(fv reflect.Value, resolved string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(resolved)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(resolved, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse %q as int: %w", resolved, err)
+		}
+		fv.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(resolved)
+		if err != nil {
+			return fmt.Errorf("parse %q as bool: %w", resolved, err)
+		}
+		fv.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field kind %s", fv.Kind())
+	}
+	return nil
+}
+
+// resolveSecret tries ref against every registered provider in order.
+// A provider reporting ErrSecretNotFound is skipped in favor of the
+// next one; any other error (a Vault outage, an auth failure, a
+// timeout) aborts resolution immediately instead of being treated the
+// same as "doesn't exist" and silently falling through to a default.
+func (l *ConfigLoader) resolveSecret(ctx context.Context, ref string) (string, bool, error) {
+	for _, p := range l.secrets {
+		val, err := p.GetSecret(ctx, ref)
+		if err == nil {
+			return val, true, nil
+		}
+		if errors.Is(err, ErrSecretNotFound) {
+			continue
+		}
+		return "", false, fmt.Errorf("resolve secret %q: %w", ref, err)
+	}
+	return "", false, nil
+}
+
+// SecretProvider fetches a single secret value by ref, the string from
+// a field's `secret` tag. The ref format is provider-specific (a Vault
+// "path#key", an ARN for Secrets Manager, a file name under
+// KENGINE_SECRETS_DIR). Implementations must return ErrSecretNotFound
+// (or an error wrapping it) when ref simply doesn't exist, so
+// ConfigLoader can distinguish that from a provider-side failure.
+type SecretProvider interface {
+	GetSecret(ctx context.Context, ref string) (string, error)
+}
+
+// ErrSecretNotFound is returned by a SecretProvider when ref does not
+// exist. ConfigLoader treats this as "try the next source"; any other
+// error aborts resolution instead of silently falling through to a
+// default the way a transient Vault outage otherwise would.
+var ErrSecretNotFound = errors.New("directory: secret not found")
+
+// VaultSecretProvider resolves secrets from a KV v2 mount over Vault's
+// HTTP API. refs are "kv/data/<path>#<key>"; the mount and path are
+// passed straight through to Vault's /v1/<path> endpoint.
+type VaultSecretProvider struct {
+	Addr       string
+	Token      string
+	HTTPClient *http.Client
+}
+
+func NewVaultSecretProvider(addr, token string) *VaultSecretProvider {
+	return &VaultSecretProvider{Addr: addr, Token: token, HTTPClient: http.DefaultClient}
+}
+
+func (p *VaultSecretProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok {
+		return "", fmt.Errorf("directory: vault secret ref %q missing '#key'", ref)
+	}
+
+	url := strings.TrimRight(p.Addr, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("directory: build vault request for %q: %w", path, err)
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("directory: vault request for %q: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("directory: vault request for %q returned %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("directory: decode vault response for %q: %w", path, err)
+	}
+	val, ok := body.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: vault secret %q has no key %q", ErrSecretNotFound, path, key)
+	}
+	return val, nil
+}
+
+// AWSSecretsManagerClient is the subset of *secretsmanager.Client that
+// AWSSecretsManagerProvider depends on, so callers can pass a real SDK
+// client without this package importing the SDK's request/response
+// types directly.
+type AWSSecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, secretID string) (string, error)
+}
+
+// AWSSecretsManagerProvider resolves secrets from AWS Secrets Manager.
+// refs are a secret name or ARN, optionally "<name>#<key>" to pull one
+// field out of a JSON secret value.
+type AWSSecretsManagerProvider struct {
+	client AWSSecretsManagerClient
+}
+
+func NewAWSSecretsManagerProvider(client AWSSecretsManagerClient) *AWSSecretsManagerProvider {
+	return &AWSSecretsManagerProvider{client: client}
+}
+
+func (p *AWSSecretsManagerProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	name, key, hasKey := strings.Cut(ref, "#")
+	raw, err := p.client.GetSecretValue(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("directory: get secret %q from AWS Secrets Manager: %w", name, err)
+	}
+	if !hasKey {
+		return raw, nil
+	}
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return "", fmt.Errorf("directory: secret %q is not a JSON object, cannot extract key %q: %w", name, key, err)
+	}
+	val, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%w: secret %q has no key %q", ErrSecretNotFound, name, key)
+	}
+	return val, nil
+}
+
+// FileSecretProvider resolves secrets from files mounted under a
+// directory, the pattern used by Kubernetes secret volumes. refs are
+// the file name relative to Dir.
+type FileSecretProvider struct {
+	Dir string
+}
+
+// NewFileSecretProviderFromEnv builds a FileSecretProvider rooted at
+// KENGINE_SECRETS_DIR, or returns nil, false if that variable is unset.
+func NewFileSecretProviderFromEnv() (*FileSecretProvider, bool) {
+	dir, has := os.LookupEnv("KENGINE_SECRETS_DIR")
+	if !has {
+		return nil, false
+	}
+	return &FileSecretProvider{Dir: dir}, true
+}
+
+func (p *FileSecretProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.Dir, ref))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("directory: read secret file %q: %w", ref, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
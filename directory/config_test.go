@@ -0,0 +1,125 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type loaderTestConfig struct {
+	Host string `env:"DIRECTORY_TEST_HOST" default:"fallback-host"`
+	Pass string `env:"DIRECTORY_TEST_PASS" secret:"test/pass" default:""`
+}
+
+type fakeSecretProvider struct {
+	values map[string]string
+	err    error
+}
+
+func (f fakeSecretProvider) GetSecret(ctx context.Context, ref string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	val, ok := f.values[ref]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return val, nil
+}
+
+func TestConfigLoaderEnvOverridesDefault(t *testing.T) {
+	loader, err := NewConfigLoader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg loaderTestConfig
+	if err := loader.Load(context.Background(), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Host != "fallback-host" {
+		t.Fatalf("expected default to apply, got %q", cfg.Host)
+	}
+
+	t.Setenv("DIRECTORY_TEST_HOST", "from-env")
+	if err := loader.Load(context.Background(), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Host != "from-env" {
+		t.Fatalf("expected env to override default, got %q", cfg.Host)
+	}
+}
+
+func TestConfigLoaderSecretOverridesEnv(t *testing.T) {
+	loader, err := NewConfigLoader(WithSecretProvider(fakeSecretProvider{
+		values: map[string]string{"test/pass": "from-secret"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DIRECTORY_TEST_PASS", "from-env")
+
+	var cfg loaderTestConfig
+	if err := loader.Load(context.Background(), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Pass != "from-secret" {
+		t.Fatalf("expected the secret provider to win over env, got %q", cfg.Pass)
+	}
+}
+
+func TestConfigLoaderMissingRequiredFieldIsAnError(t *testing.T) {
+	type required struct {
+		Value string `env:"DIRECTORY_TEST_REQUIRED_VALUE"`
+	}
+
+	loader, err := NewConfigLoader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg required
+	if err := loader.Load(context.Background(), &cfg); err == nil {
+		t.Fatal("expected an error for a field with no value and no default")
+	}
+}
+
+// TestConfigLoaderPropagatesRealSecretProviderErrors ensures a
+// transient provider failure (a Vault outage, an auth error) is
+// surfaced as a Load error rather than being treated as "secret
+// missing" and silently falling back to the field's default.
+func TestConfigLoaderPropagatesRealSecretProviderErrors(t *testing.T) {
+	boom := errors.New("vault is down")
+	loader, err := NewConfigLoader(WithSecretProvider(fakeSecretProvider{err: boom}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg loaderTestConfig
+	err = loader.Load(context.Background(), &cfg)
+	if err == nil {
+		t.Fatal("expected a provider outage to be surfaced, not silently defaulted")
+	}
+	if errors.Is(err, ErrSecretNotFound) {
+		t.Fatal("a provider outage must not be conflated with a missing secret")
+	}
+}
+
+func TestConfigLoaderSkipsNotFoundSecretProviders(t *testing.T) {
+	loader, err := NewConfigLoader(
+		WithSecretProvider(fakeSecretProvider{values: map[string]string{}}),
+		WithSecretProvider(fakeSecretProvider{values: map[string]string{"test/pass": "from-second-provider"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg loaderTestConfig
+	if err := loader.Load(context.Background(), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Pass != "from-second-provider" {
+		t.Fatalf("expected fallthrough to the next provider on ErrSecretNotFound, got %q", cfg.Pass)
+	}
+}
+
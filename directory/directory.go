@@ -2,9 +2,12 @@ package directory
 
 import (
 	"context"
+	"fmt"
 	"os"
-	"strconv"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/khulnasoft-lab/kengine_utils/log"
 )
@@ -21,33 +24,52 @@ const (
 type NamespaceID string
 
 type RedisConfig struct {
+	Host     string `env:"KENGINE_REDIS_HOST" default:"localhost"`
+	Port     string `env:"KENGINE_REDIS_PORT" default:"6379"`
+	Password string `env:"KENGINE_REDIS_PASSWORD" secret:"kv/data/kengine/redis#password" default:""`
+	Database int    `env:"KENGINE_REDIS_DB_NUMBER" default:"0"`
+
+	// Endpoint is the dialable Redis address, computed from Host/Port
+	// once loading completes. It is not itself tagged: Host and Port
+	// exist so the ConfigLoader can resolve them independently, but
+	// Endpoint is kept as a plain field (not a method) since callers
+	// outside this package read it directly.
 	Endpoint string
-	Password string
-	Database int
 }
 
 type Neo4jConfig struct {
+	Host     string `env:"KENGINE_NEO4J_HOST" default:"localhost"`
+	BoltPort string `env:"KENGINE_NEO4J_BOLT_PORT" default:"7687"`
+	Username string `env:"KENGINE_NEO4J_USER" default:"neo4j"`
+	Password string `env:"KENGINE_NEO4J_PASSWORD" secret:"kv/data/kengine/neo4j#password" default:""`
+
+	// Endpoint is the bolt:// URI Neo4j drivers expect, computed from
+	// Host/BoltPort once loading completes. See RedisConfig.Endpoint.
 	Endpoint string
-	Username string
-	Password string
 }
 
 type PostgresqlConfig struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
-	Database string
-	SslMode  string
+	Host     string `env:"KENGINE_POSTGRES_USER_DB_HOST" default:"localhost"`
+	Port     int    `env:"KENGINE_POSTGRES_USER_DB_PORT" default:"5432"`
+	Username string `env:"KENGINE_POSTGRES_USER_DB_USER" default:"kengine"`
+	Password string `env:"KENGINE_POSTGRES_USER_DB_PASSWORD" secret:"kv/data/kengine/postgres#password" default:""`
+	Database string `env:"KENGINE_POSTGRES_USER_DB_NAME" default:""`
+	SslMode  string `env:"KENGINE_POSTGRES_USER_DB_SSLMODE" default:""`
 }
 
 type FileServerConfig struct {
-	Endpoint   string
-	Username   string
-	Password   string
-	BucketName string
-	Secure     bool
-	Region     string
+	Host       string `env:"KENGINE_FILE_SERVER_HOST" default:"kengine-file-server"`
+	Port       string `env:"KENGINE_FILE_SERVER_PORT" default:"9000"`
+	Username   string `env:"KENGINE_FILE_SERVER_USER" default:"kengine"`
+	Password   string `env:"KENGINE_FILE_SERVER_PASSWORD" secret:"kv/data/kengine/file-server#password" default:""`
+	BucketName string `env:"KENGINE_FILE_SERVER_BUCKET" default:""`
+	Secure     bool   `env:"KENGINE_FILE_SERVER_SECURE" default:"false"`
+	Region     string `env:"KENGINE_FILE_SERVER_REGION" default:""`
+
+	// Endpoint is the dialable file server address, computed from
+	// Host/Port once loading completes. Managed S3 has no port to
+	// append. See RedisConfig.Endpoint.
+	Endpoint string
 }
 
 type DBConfigs struct {
@@ -68,7 +90,17 @@ func init() {
 	directory = namespaceDirectory{
 		Directory: map[NamespaceID]DBConfigs{},
 	}
-	fileServerCfg := initFileServer()
+
+	ctx := context.Background()
+	loader, err := newDefaultConfigLoader()
+	if err != nil {
+		panic(fmt.Errorf("directory: building config loader: %w", err))
+	}
+
+	fileServerCfg, err := initFileServer(ctx, loader)
+	if err != nil {
+		panic(err)
+	}
 
 	saasMode := false
 	saasModeOn, has := os.LookupEnv("KENGINE_SAAS_MODE")
@@ -80,9 +112,21 @@ func init() {
 
 	directory.Lock()
 	if !saasMode {
-		redisCfg := initRedis()
-		neo4jCfg := initNeo4j()
-		postgresqlCfg := initPosgresql()
+		redisCfg, err := initRedis(ctx, loader)
+		if err != nil {
+			directory.Unlock()
+			panic(err)
+		}
+		neo4jCfg, err := initNeo4j(ctx, loader)
+		if err != nil {
+			directory.Unlock()
+			panic(err)
+		}
+		postgresqlCfg, err := initPosgresql(ctx, loader)
+		if err != nil {
+			directory.Unlock()
+			panic(err)
+		}
 		directory.Directory[NonSaaSDirKey] = DBConfigs{
 			Redis:      &redisCfg,
 			Neo4j:      &neo4jCfg,
@@ -112,42 +156,86 @@ func GetAllNamespaces() []NamespaceID {
 	return namespaces
 }
 
-func GetDatabaseConfig(ctx context.Context) (*DBConfigs, error) {
-	ns, err := ExtractNamespace(ctx)
+func GetDatabaseConfig(ctx context.Context) (_ *DBConfigs, err error) {
+	ctx, span := tracer().Start(ctx, "directory.GetDatabaseConfig")
+	start := time.Now()
+	var ns NamespaceID
+	result := "found"
+	defer func() {
+		if err != nil {
+			result = "error"
+			span.RecordError(err)
+		}
+		span.SetAttributes(attribute.String("result", result))
+		span.End()
+		recordLookup(ctx, ns, result, start)
+	}()
+
+	ns, err = ExtractNamespace(ctx)
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(attribute.String("namespace", string(ns)))
 
 	directory.RLock()
-	defer directory.RUnlock()
-
 	cfg, found := directory.Directory[ns]
-	if !found {
-		return nil, ErrNamespaceNotFound
+	directory.RUnlock()
+	if found {
+		return &cfg, nil
+	}
+
+	if cached, ok := tenantCacheStore.get(ns); ok {
+		result = "cache_hit"
+		return &cached, nil
+	}
+
+	result = "hydrated"
+	cfg, err = resolveNamespace(ctx, ns)
+	if err != nil {
+		return nil, err
 	}
 	return &cfg, nil
 }
 
 func ForEachNamespace(applyFn func(ctx context.Context) (string, error)) {
+	_, span := tracer().Start(context.Background(), "directory.ForEachNamespace")
+	defer span.End()
+
 	namespaces := GetAllNamespaces()
 	var err error
 	var msg string
 	for _, ns := range namespaces {
 		msg, err = applyFn(NewContextWithNameSpace(ns))
 		if err != nil {
+			span.RecordError(err)
 			log.Error().Err(err).Msg(msg)
 		}
 	}
 }
 
 func FetchNamespace(email string) NamespaceID {
+	ctx, span := tracer().Start(context.Background(), "directory.FetchNamespace")
+	defer span.End()
+
 	namespaces := GetAllNamespaces()
 	if len(namespaces) == 1 && namespaces[0] == NonSaaSDirKey {
 		return NonSaaSDirKey
-	} else { //nolint:staticcheck
-		// TODO: Fetch namespace for SaaS tenant
 	}
-	return ""
+
+	r := currentRegistry()
+	if r == nil {
+		log.Warn().Str("email", email).Msg("no TenantRegistry configured, cannot resolve SaaS tenant")
+		span.RecordError(errNoTenantRegistry)
+		return ""
+	}
+	ns, err := r.ResolveNamespace(ctx, email)
+	if err != nil {
+		log.Error().Err(err).Str("email", email).Msg("failed to resolve tenant namespace")
+		span.RecordError(err)
+		return ""
+	}
+	span.SetAttributes(attribute.String("namespace", string(ns)))
+	return ns
 }
 
 func IsNonSaaSDeployment() bool {
@@ -158,149 +246,69 @@ func IsNonSaaSDeployment() bool {
 	return false
 }
 
-func initRedis() RedisConfig {
-	redisHost, has := os.LookupEnv("KENGINE_REDIS_HOST")
-	if !has {
-		redisHost = "localhost"
-		log.Warn().Msgf("KENGINE_REDIS_HOST defaults to: %v", redisHost)
-	}
-	redisPort, has := os.LookupEnv("KENGINE_REDIS_PORT")
-	if !has {
-		redisPort = "6379"
-		log.Warn().Msgf("KENGINE_REDIS_PORT defaults to: %v", redisPort)
-	}
-	redisEndpoint := redisHost + ":" + redisPort
-	redisPassword := os.Getenv("KENGINE_REDIS_PASSWORD")
-	redisDBNumber := 0
-	var err error
-	redisDBNumberStr := os.Getenv("KENGINE_REDIS_DB_NUMBER")
-	if redisDBNumberStr != "" {
-		redisDBNumber, err = strconv.Atoi(redisDBNumberStr)
-		if err != nil {
-			redisDBNumber = 0
-		}
-	}
-	return RedisConfig{
-		Endpoint: redisEndpoint,
-		Password: redisPassword,
-		Database: redisDBNumber,
-	}
+// newDefaultConfigLoader builds the ConfigLoader used by init: an
+// optional config file named by KENGINE_CONFIG_FILE, plus every secret
+// provider that has enough environment to construct (Vault via
+// VAULT_ADDR/VAULT_TOKEN, file-mounted secrets via KENGINE_SECRETS_DIR).
+// AWS Secrets Manager has no ambient construction path here since it
+// needs an SDK client; callers that want it call WithSecretProvider
+// themselves via a custom ConfigLoader.
+func newDefaultConfigLoader() (*ConfigLoader, error) {
+	opts := []ConfigLoaderOption{}
+	if path, has := os.LookupEnv("KENGINE_CONFIG_FILE"); has {
+		opts = append(opts, WithConfigFile(path))
+	}
+	if addr, has := os.LookupEnv("VAULT_ADDR"); has {
+		opts = append(opts, WithSecretProvider(NewVaultSecretProvider(addr, os.Getenv("VAULT_TOKEN"))))
+	}
+	if fileProvider, has := NewFileSecretProviderFromEnv(); has {
+		opts = append(opts, WithSecretProvider(fileProvider))
+	}
+	return NewConfigLoader(opts...)
 }
 
-func initFileServer() FileServerConfig {
-	fileServerHost, has := os.LookupEnv("KENGINE_FILE_SERVER_HOST")
-	if !has {
-		fileServerHost = "kengine-file-server"
-		log.Warn().Msgf("KENGINE_FILE_SERVER_HOST defaults to: %v", fileServerHost)
-	}
-	fileServerPort, has := os.LookupEnv("KENGINE_FILE_SERVER_PORT")
-	if !has {
-		fileServerPort = "9000"
-		log.Warn().Msgf("KENGINE_FILE_SERVER_PORT defaults to: %v", fileServerPort)
-	}
-
-	fileServerUser := os.Getenv("KENGINE_FILE_SERVER_USER")
-	if fileServerUser == "" {
-		fileServerUser = "kengine"
-		log.Warn().Msgf("KENGINE_FILE_SERVER_USER defaults to: %v", fileServerUser)
-	}
-	fileServerPassword := os.Getenv("KENGINE_FILE_SERVER_PASSWORD")
-	if fileServerPassword == "" {
-		fileServerPassword = "kengine"
-		log.Warn().Msg("using default file server password")
-	}
-	fileServerBucket := os.Getenv("KENGINE_FILE_SERVER_BUCKET")
-	fileServerRegion := os.Getenv("KENGINE_FILE_SERVER_REGION")
-	fileServerSecure := os.Getenv("KENGINE_FILE_SERVER_SECURE")
-
-	fileServerEndpoint := fileServerHost
-	if fileServerHost != "s3.amazonaws.com" {
-		fileServerEndpoint = fileServerHost + ":" + fileServerPort
-	}
-
-	if fileServerSecure == "" {
-		fileServerSecure = "false"
-	}
-	isSecure, err := strconv.ParseBool(fileServerSecure)
-	if err != nil {
-		isSecure = false
-		log.Warn().Msgf("KENGINE_FILE_SERVER_SECURE defaults to: %v (%v)", isSecure, err.Error())
-	}
-	return FileServerConfig{
-		Endpoint:   fileServerEndpoint,
-		Username:   fileServerUser,
-		Password:   fileServerPassword,
-		BucketName: fileServerBucket,
-		Secure:     isSecure,
-		Region:     fileServerRegion,
-	}
+// initRedis, initNeo4j, initPosgresql, and initFileServer report a
+// malformed config as an error rather than panicking themselves: init
+// panics on that error directly for fail-fast startup behavior, but
+// Reload calls these same helpers from watchConfig's background
+// goroutine on every SIGHUP or config file change, where an unrecovered
+// panic would take the whole process down over a single bad edit to a
+// mounted config file.
+func initRedis(ctx context.Context, loader *ConfigLoader) (RedisConfig, error) {
+	var cfg RedisConfig
+	if err := loader.Load(ctx, &cfg); err != nil {
+		return RedisConfig{}, fmt.Errorf("directory: invalid redis config: %w", err)
+	}
+	cfg.Endpoint = cfg.Host + ":" + cfg.Port
+	return cfg, nil
 }
 
-func initPosgresql() PostgresqlConfig {
-	var err error
-	postgresHost, has := os.LookupEnv("KENGINE_POSTGRES_USER_DB_HOST")
-	if !has {
-		postgresHost = "localhost"
-		log.Warn().Msgf("KENGINE_POSTGRES_USER_DB_HOST defaults to: %v", postgresHost)
+func initFileServer(ctx context.Context, loader *ConfigLoader) (FileServerConfig, error) {
+	var cfg FileServerConfig
+	if err := loader.Load(ctx, &cfg); err != nil {
+		return FileServerConfig{}, fmt.Errorf("directory: invalid file server config: %w", err)
 	}
-	postgresPort := 5432
-	postgresPortStr := os.Getenv("KENGINE_POSTGRES_USER_DB_PORT")
-	if postgresPortStr == "" {
-		log.Warn().Msgf("KENGINE_POSTGRES_USER_DB_PORT defaults to: %d", postgresPort)
+	if cfg.Host == "s3.amazonaws.com" {
+		cfg.Endpoint = cfg.Host
 	} else {
-		postgresPort, err = strconv.Atoi(postgresPortStr)
-		if err != nil {
-			postgresPort = 5432
-		}
-	}
-	postgresUsername := os.Getenv("KENGINE_POSTGRES_USER_DB_USER")
-	if postgresUsername == "" {
-		postgresUsername = "kengine"
-		log.Warn().Msgf("KENGINE_POSTGRES_USER_DB_USER defaults to: %v", postgresUsername)
-	}
-	postgresPassword := os.Getenv("KENGINE_POSTGRES_USER_DB_PASSWORD")
-	if postgresPassword == "" {
-		postgresPassword = "kengine"
-		log.Warn().Msg("using default postgres password")
-	}
-	postgresDatabase := os.Getenv("KENGINE_POSTGRES_USER_DB_NAME")
-	postgresSslMode := os.Getenv("KENGINE_POSTGRES_USER_DB_SSLMODE")
-
-	return PostgresqlConfig{
-		Host:     postgresHost,
-		Port:     postgresPort,
-		Username: postgresUsername,
-		Password: postgresPassword,
-		Database: postgresDatabase,
-		SslMode:  postgresSslMode,
+		cfg.Endpoint = cfg.Host + ":" + cfg.Port
 	}
+	return cfg, nil
 }
 
-func initNeo4j() Neo4jConfig {
-	neo4jHost, has := os.LookupEnv("KENGINE_NEO4J_HOST")
-	if !has {
-		neo4jHost = "localhost"
-		log.Warn().Msgf("KENGINE_NEO4J_HOST defaults to: %v", neo4jHost)
-	}
-	neo4jBoltPort, has := os.LookupEnv("KENGINE_NEO4J_BOLT_PORT")
-	if !has {
-		neo4jBoltPort = "7687"
-		log.Warn().Msgf("KENGINE_NEO4J_BOLT_PORT defaults to: %v", neo4jBoltPort)
+func initPosgresql(ctx context.Context, loader *ConfigLoader) (PostgresqlConfig, error) {
+	var cfg PostgresqlConfig
+	if err := loader.Load(ctx, &cfg); err != nil {
+		return PostgresqlConfig{}, fmt.Errorf("directory: invalid postgres config: %w", err)
 	}
-	neo4jEndpoint := "bolt://" + neo4jHost + ":" + neo4jBoltPort
-	neo4jUsername := os.Getenv("KENGINE_NEO4J_USER")
-	if neo4jUsername == "" {
-		neo4jUsername = "neo4j"
-		log.Warn().Msgf("KENGINE_NEO4J_USER defaults to: %v", neo4jUsername)
-	}
-	neo4jPassword := os.Getenv("KENGINE_NEO4J_PASSWORD")
-	if neo4jPassword == "" {
-		neo4jPassword = "e16908ffa5b9f8e9d4ed"
-		log.Warn().Msg("using default neo4j password")
-	}
-	return Neo4jConfig{
-		Endpoint: neo4jEndpoint,
-		Username: neo4jUsername,
-		Password: neo4jPassword,
+	return cfg, nil
+}
+
+func initNeo4j(ctx context.Context, loader *ConfigLoader) (Neo4jConfig, error) {
+	var cfg Neo4jConfig
+	if err := loader.Load(ctx, &cfg); err != nil {
+		return Neo4jConfig{}, fmt.Errorf("directory: invalid neo4j config: %w", err)
 	}
+	cfg.Endpoint = "bolt://" + cfg.Host + ":" + cfg.BoltPort
+	return cfg, nil
 }
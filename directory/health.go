@@ -0,0 +1,240 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/redis/go-redis/v9"
+)
+
+// componentProbeTimeout bounds how long a single backend probe may
+// take, so one unreachable component can't stall the whole HealthCheck.
+const componentProbeTimeout = 3 * time.Second
+
+// ComponentKind identifies which backend a ComponentHealth describes.
+type ComponentKind string
+
+const (
+	ComponentRedis      ComponentKind = "redis"
+	ComponentNeo4j      ComponentKind = "neo4j"
+	ComponentPostgres   ComponentKind = "postgres"
+	ComponentFileServer ComponentKind = "file_server"
+)
+
+// ComponentHealth is the result of probing a single backend configured
+// for a namespace. Err is a string, not an error, so ComponentHealth
+// marshals cleanly to JSON for a /healthz or /readyz response instead
+// of dropping the failure reason (error has no exported fields).
+type ComponentHealth struct {
+	Namespace NamespaceID
+	Component ComponentKind
+	Healthy   bool
+	Err       string
+	Latency   time.Duration
+}
+
+// HealthCheck pings every backend configured across every namespace
+// (Redis PING, Neo4j "RETURN 1", Postgres "SELECT 1", a file server
+// bucket HEAD) and returns one ComponentHealth per backend probed. It
+// is meant to back a `/healthz` handler.
+func HealthCheck(ctx context.Context) []ComponentHealth {
+	directory.RLock()
+	snapshot := make(map[NamespaceID]DBConfigs, len(directory.Directory))
+	for ns, cfg := range directory.Directory {
+		snapshot[ns] = cfg
+	}
+	directory.RUnlock()
+
+	// SaaS tenant namespaces resolved lazily through GetDatabaseConfig
+	// live only in tenantCacheStore, not directory.Directory (see
+	// resolveNamespace); without this, a health report would silently
+	// omit every tenant that hadn't been RegisterNamespace'd.
+	for ns, cfg := range tenantCacheStore.snapshot() {
+		if _, already := snapshot[ns]; !already {
+			snapshot[ns] = cfg
+		}
+	}
+
+	var results []ComponentHealth
+	for ns, cfg := range snapshot {
+		results = append(results, probeNamespace(ctx, ns, cfg)...)
+	}
+	return results
+}
+
+// WaitReady blocks until every backend configured for the caller's
+// namespace (per ExtractNamespace) is reachable, retrying with backoff
+// between attempts. It returns early with ctx.Err() if ctx is canceled
+// or times out first. Use this from entrypoints that would otherwise
+// race a database coming up.
+func WaitReady(ctx context.Context, backoff func(attempt int) time.Duration) error {
+	ns, err := ExtractNamespace(ctx)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Resolve through GetDatabaseConfig rather than indexing
+		// directory.Directory directly: a SaaS tenant namespace that
+		// hasn't been RegisterNamespace'd lives only in
+		// tenantCacheStore or the TenantRegistry, and indexing the map
+		// here would report it not found on the very first attempt.
+		cfg, err := GetDatabaseConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		if allHealthy(probeNamespace(ctx, ns, *cfg)) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+// Validate checks every namespace's DBConfigs for fields that parsed
+// but don't make sense together (an empty host, a file server with no
+// bucket), returning every problem found rather than stopping at the
+// first. Call it at startup, after init, to fail fast on bad config
+// instead of discovering it on the first request.
+func Validate() error {
+	directory.RLock()
+	defer directory.RUnlock()
+
+	var errs []error
+	for ns, cfg := range directory.Directory {
+		if cfg.Redis != nil && cfg.Redis.Host == "" {
+			errs = append(errs, fmt.Errorf("namespace %q: redis host is empty", ns))
+		}
+		if cfg.Neo4j != nil && cfg.Neo4j.Host == "" {
+			errs = append(errs, fmt.Errorf("namespace %q: neo4j host is empty", ns))
+		}
+		if cfg.Postgres != nil && cfg.Postgres.Host == "" {
+			errs = append(errs, fmt.Errorf("namespace %q: postgres host is empty", ns))
+		}
+		if cfg.FileServer != nil && cfg.FileServer.BucketName == "" {
+			errs = append(errs, fmt.Errorf("namespace %q: file server bucket name is empty", ns))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func allHealthy(results []ComponentHealth) bool {
+	for _, r := range results {
+		if !r.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+func probeNamespace(ctx context.Context, ns NamespaceID, cfg DBConfigs) []ComponentHealth {
+	var results []ComponentHealth
+	if cfg.Redis != nil {
+		results = append(results, probeRedis(ctx, ns, *cfg.Redis))
+	}
+	if cfg.Neo4j != nil {
+		results = append(results, probeNeo4j(ctx, ns, *cfg.Neo4j))
+	}
+	if cfg.Postgres != nil {
+		results = append(results, probePostgres(ctx, ns, *cfg.Postgres))
+	}
+	if cfg.FileServer != nil {
+		results = append(results, probeFileServer(ctx, ns, *cfg.FileServer))
+	}
+	return results
+}
+
+func probeRedis(ctx context.Context, ns NamespaceID, cfg RedisConfig) ComponentHealth {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, componentProbeTimeout)
+	defer cancel()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Endpoint,
+		Password: cfg.Password,
+		DB:       cfg.Database,
+	})
+	defer client.Close()
+
+	err := client.Ping(ctx).Err()
+	return ComponentHealth{Namespace: ns, Component: ComponentRedis, Healthy: err == nil, Err: errString(err), Latency: time.Since(start)}
+}
+
+func probeNeo4j(ctx context.Context, ns NamespaceID, cfg Neo4jConfig) ComponentHealth {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, componentProbeTimeout)
+	defer cancel()
+
+	driver, err := neo4j.NewDriverWithContext(cfg.Endpoint, neo4j.BasicAuth(cfg.Username, cfg.Password, ""))
+	if err != nil {
+		return ComponentHealth{Namespace: ns, Component: ComponentNeo4j, Err: errString(err), Latency: time.Since(start)}
+	}
+	defer driver.Close(ctx)
+
+	session := driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
+
+	_, err = session.Run(ctx, "RETURN 1", nil)
+	return ComponentHealth{Namespace: ns, Component: ComponentNeo4j, Healthy: err == nil, Err: errString(err), Latency: time.Since(start)}
+}
+
+func probePostgres(ctx context.Context, ns NamespaceID, cfg PostgresqlConfig) ComponentHealth {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, componentProbeTimeout)
+	defer cancel()
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.Username, cfg.Password),
+		Host:   fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:   "/" + cfg.Database,
+	}
+	if cfg.SslMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {cfg.SslMode}}.Encode()
+	}
+	conn, err := pgx.Connect(ctx, dsn.String())
+	if err != nil {
+		return ComponentHealth{Namespace: ns, Component: ComponentPostgres, Err: errString(err), Latency: time.Since(start)}
+	}
+	defer conn.Close(ctx)
+
+	_, err = conn.Exec(ctx, "SELECT 1")
+	return ComponentHealth{Namespace: ns, Component: ComponentPostgres, Healthy: err == nil, Err: errString(err), Latency: time.Since(start)}
+}
+
+func probeFileServer(ctx context.Context, ns NamespaceID, cfg FileServerConfig) ComponentHealth {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, componentProbeTimeout)
+	defer cancel()
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.Username, cfg.Password, ""),
+		Secure: cfg.Secure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return ComponentHealth{Namespace: ns, Component: ComponentFileServer, Err: errString(err), Latency: time.Since(start)}
+	}
+
+	_, err = client.BucketExists(ctx, cfg.BucketName)
+	return ComponentHealth{Namespace: ns, Component: ComponentFileServer, Healthy: err == nil, Err: errString(err), Latency: time.Since(start)}
+}
@@ -0,0 +1,90 @@
+package directory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestValidateAggregatesErrors checks that Validate reports every
+// namespace with a problem, not just the first one it finds.
+func TestValidateAggregatesErrors(t *testing.T) {
+	prev := directory.Directory
+	t.Cleanup(func() {
+		directory.Lock()
+		directory.Directory = prev
+		directory.Unlock()
+	})
+
+	directory.Lock()
+	directory.Directory = map[NamespaceID]DBConfigs{
+		"bad-redis":  {Redis: &RedisConfig{Host: ""}},
+		"bad-bucket": {FileServer: &FileServerConfig{BucketName: ""}},
+		"fine":       {Neo4j: &Neo4jConfig{Host: "localhost"}},
+	}
+	directory.Unlock()
+
+	err := Validate()
+	if err == nil {
+		t.Fatal("expected Validate to report the bad namespaces")
+	}
+	if !strings.Contains(err.Error(), "bad-redis") {
+		t.Errorf("expected error to mention %q, got: %v", "bad-redis", err)
+	}
+	if !strings.Contains(err.Error(), "bad-bucket") {
+		t.Errorf("expected error to mention %q, got: %v", "bad-bucket", err)
+	}
+	if strings.Contains(err.Error(), "\"fine\"") {
+		t.Errorf("did not expect the well-formed namespace to be reported, got: %v", err)
+	}
+
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) && len(joined.Unwrap()) != 2 {
+		t.Errorf("expected exactly 2 joined errors, got %d", len(joined.Unwrap()))
+	}
+}
+
+// TestValidateNoErrorsForWellFormedConfig ensures Validate doesn't flag
+// namespaces whose configs are all filled in.
+func TestValidateNoErrorsForWellFormedConfig(t *testing.T) {
+	prev := directory.Directory
+	t.Cleanup(func() {
+		directory.Lock()
+		directory.Directory = prev
+		directory.Unlock()
+	})
+
+	directory.Lock()
+	directory.Directory = map[NamespaceID]DBConfigs{
+		"fine": {
+			Redis:      &RedisConfig{Host: "localhost"},
+			Neo4j:      &Neo4jConfig{Host: "localhost"},
+			Postgres:   &PostgresqlConfig{Host: "localhost"},
+			FileServer: &FileServerConfig{BucketName: "bucket"},
+		},
+	}
+	directory.Unlock()
+
+	if err := Validate(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+}
+
+func TestAllHealthy(t *testing.T) {
+	cases := []struct {
+		name    string
+		results []ComponentHealth
+		want    bool
+	}{
+		{"empty", nil, true},
+		{"all healthy", []ComponentHealth{{Healthy: true}, {Healthy: true}}, true},
+		{"one unhealthy", []ComponentHealth{{Healthy: true}, {Healthy: false}}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := allHealthy(tc.results); got != tc.want {
+				t.Errorf("allHealthy(%v) = %v, want %v", tc.results, got, tc.want)
+			}
+		})
+	}
+}
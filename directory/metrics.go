@@ -0,0 +1,122 @@
+package directory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/khulnasoft-lab/kengine_utils/log"
+)
+
+// instrumentationName identifies this package to OpenTelemetry's
+// Tracer/Meter lookups.
+const instrumentationName = "github.com/khulnasoft-lab/kengine_utils/directory"
+
+var (
+	providersMu    sync.RWMutex
+	meterProvider  metric.MeterProvider = otel.GetMeterProvider()
+	tracerProvider trace.TracerProvider = otel.GetTracerProvider()
+)
+
+// SetMeterProvider installs the OpenTelemetry MeterProvider used to
+// record directory metrics (directory_lookup_total,
+// directory_lookup_duration_seconds, directory_namespaces). Export
+// these to Prometheus by registering the provider's reader with the
+// OTel Prometheus exporter. Defaults to the global provider set via
+// otel.SetMeterProvider.
+func SetMeterProvider(p metric.MeterProvider) {
+	providersMu.Lock()
+	meterProvider = p
+	providersMu.Unlock()
+	initInstruments()
+}
+
+// SetTracerProvider installs the OpenTelemetry TracerProvider used to
+// trace directory lookups. Defaults to the global provider set via
+// otel.SetTracerProvider.
+func SetTracerProvider(p trace.TracerProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	tracerProvider = p
+}
+
+func tracer() trace.Tracer {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return tracerProvider.Tracer(instrumentationName)
+}
+
+type instruments struct {
+	lookupTotal   metric.Int64Counter
+	lookupLatency metric.Float64Histogram
+}
+
+var (
+	instrumentsMu sync.RWMutex
+	current       *instruments
+)
+
+func init() {
+	initInstruments()
+}
+
+func initInstruments() {
+	providersMu.RLock()
+	meter := meterProvider.Meter(instrumentationName)
+	providersMu.RUnlock()
+
+	lookupTotal, err := meter.Int64Counter("directory_lookup_total",
+		metric.WithDescription("Total directory lookups, by namespace and result"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create directory_lookup_total counter")
+	}
+	lookupLatency, err := meter.Float64Histogram("directory_lookup_duration_seconds",
+		metric.WithDescription("Directory lookup latency in seconds"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create directory_lookup_duration_seconds histogram")
+	}
+	_, err = meter.Int64ObservableGauge("directory_namespaces",
+		metric.WithDescription("Number of namespaces currently registered"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(namespaceCount()))
+			return nil
+		}),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create directory_namespaces gauge")
+	}
+
+	instrumentsMu.Lock()
+	current = &instruments{lookupTotal: lookupTotal, lookupLatency: lookupLatency}
+	instrumentsMu.Unlock()
+}
+
+func namespaceCount() int {
+	directory.RLock()
+	defer directory.RUnlock()
+	return len(directory.Directory)
+}
+
+func recordLookup(ctx context.Context, ns NamespaceID, result string, start time.Time) {
+	instrumentsMu.RLock()
+	inst := current
+	instrumentsMu.RUnlock()
+	if inst == nil {
+		return
+	}
+
+	attrs := metric.WithAttributes(
+		attribute.String("namespace", string(ns)),
+		attribute.String("result", result),
+	)
+	inst.lookupTotal.Add(ctx, 1, attrs)
+	inst.lookupLatency.Record(ctx, time.Since(start).Seconds(), attrs)
+}
+
+var errNoTenantRegistry = errors.New("directory: no TenantRegistry configured")
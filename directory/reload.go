@@ -0,0 +1,188 @@
+package directory
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/khulnasoft-lab/kengine_utils/log"
+)
+
+// ConfigChangeEvent is published on every channel returned by Subscribe
+// when Reload observes a namespace's DBConfigs actually change.
+type ConfigChangeEvent struct {
+	Namespace  NamespaceID
+	Generation uint64
+	Configs    DBConfigs
+}
+
+var (
+	generationsMu sync.Mutex
+	generations   = map[NamespaceID]uint64{}
+
+	subscribersMu sync.Mutex
+	subscribers   []chan ConfigChangeEvent
+)
+
+func init() {
+	if os.Getenv("KENGINE_CONFIG_WATCH") == "on" {
+		go watchConfig()
+	}
+}
+
+// Reload re-runs the non-SaaS backend initializers (Redis, Neo4j,
+// Postgres, the global file server) against the current environment,
+// config file, and secret providers, and publishes a ConfigChangeEvent
+// for every namespace whose DBConfigs actually changed. SaaS tenant
+// namespaces are not touched here; use RefreshNamespace for those.
+func Reload(ctx context.Context) error {
+	loader, err := newDefaultConfigLoader()
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("KENGINE_SAAS_MODE") != "on" {
+		redisCfg, err := initRedis(ctx, loader)
+		if err != nil {
+			return err
+		}
+		neo4jCfg, err := initNeo4j(ctx, loader)
+		if err != nil {
+			return err
+		}
+		postgresqlCfg, err := initPosgresql(ctx, loader)
+		if err != nil {
+			return err
+		}
+		applyReload(NonSaaSDirKey, DBConfigs{
+			Redis:    &redisCfg,
+			Neo4j:    &neo4jCfg,
+			Postgres: &postgresqlCfg,
+		})
+	}
+
+	fileServerCfg, err := initFileServer(ctx, loader)
+	if err != nil {
+		return err
+	}
+	applyReload(GlobalDirKey, DBConfigs{FileServer: &fileServerCfg})
+	return nil
+}
+
+// applyReload swaps in next for ns if it differs from the directory's
+// current entry, bumping ns's generation and publishing a
+// ConfigChangeEvent to every subscriber.
+func applyReload(ns NamespaceID, next DBConfigs) {
+	directory.Lock()
+	prev, had := directory.Directory[ns]
+	changed := !had || !reflect.DeepEqual(prev, next)
+	if changed {
+		directory.Directory[ns] = next
+	}
+	directory.Unlock()
+
+	if !changed {
+		return
+	}
+	tenantCacheStore.invalidate(ns)
+
+	generationsMu.Lock()
+	generations[ns]++
+	gen := generations[ns]
+	generationsMu.Unlock()
+
+	publish(ConfigChangeEvent{Namespace: ns, Generation: gen, Configs: next})
+}
+
+// Generation returns how many times ns's DBConfigs have changed since
+// startup, so long-lived consumers (a pooled redis.Client, a pgxpool)
+// can tell whether the handle they hold is stale.
+func Generation(ns NamespaceID) uint64 {
+	generationsMu.Lock()
+	defer generationsMu.Unlock()
+	return generations[ns]
+}
+
+// Subscribe returns a channel that receives a ConfigChangeEvent every
+// time Reload observes a namespace's DBConfigs change, so connection
+// pools can re-dial without a process restart. The channel is buffered;
+// a subscriber that falls behind has events dropped rather than
+// blocking Reload, with a warning logged for each drop.
+func Subscribe() <-chan ConfigChangeEvent {
+	ch := make(chan ConfigChangeEvent, 16)
+	subscribersMu.Lock()
+	subscribers = append(subscribers, ch)
+	subscribersMu.Unlock()
+	return ch
+}
+
+func publish(evt ConfigChangeEvent) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for _, ch := range subscribers {
+		select {
+		case ch <- evt:
+		default:
+			log.Warn().Str("namespace", string(evt.Namespace)).Msg("dropping config change event, subscriber channel full")
+		}
+	}
+}
+
+// watchConfig blocks forever, calling Reload whenever SIGHUP arrives or
+// the file named by KENGINE_CONFIG_FILE changes on disk. It only runs
+// when KENGINE_CONFIG_WATCH=on.
+func watchConfig() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	configFile := os.Getenv("KENGINE_CONFIG_FILE")
+	var watcher *fsnotify.Watcher
+	if configFile != "" {
+		var err error
+		watcher, err = fsnotify.NewWatcher()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to start config file watcher, SIGHUP reload still active")
+		} else {
+			defer watcher.Close()
+			if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+				log.Error().Err(err).Msg("failed to watch config file directory, SIGHUP reload still active")
+			}
+		}
+	}
+
+	for {
+		var reason string
+		select {
+		case <-sigCh:
+			reason = "SIGHUP"
+		case evt, ok := <-watcherEvents(watcher):
+			if !ok {
+				return
+			}
+			if filepath.Clean(evt.Name) != filepath.Clean(configFile) {
+				continue
+			}
+			reason = "config file changed: " + evt.Name
+		}
+
+		log.Info().Str("reason", reason).Msg("reloading directory config")
+		if err := Reload(context.Background()); err != nil {
+			log.Error().Err(err).Msg("config reload failed")
+		}
+	}
+}
+
+// watcherEvents returns w.Events, or a nil channel if w is nil so the
+// select in watchConfig simply never takes that branch.
+func watcherEvents(w *fsnotify.Watcher) chan fsnotify.Event {
+	if w == nil {
+		return nil
+	}
+	return w.Events
+}
@@ -0,0 +1,53 @@
+package directory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyReloadOnlyPublishesOnChange(t *testing.T) {
+	const ns = NamespaceID("reload-test")
+	sub := Subscribe()
+	startGen := Generation(ns)
+
+	cfg := DBConfigs{Redis: &RedisConfig{Host: "localhost", Port: "6379"}}
+	applyReload(ns, cfg)
+	if Generation(ns) != startGen+1 {
+		t.Fatalf("expected generation to advance on first apply, got %d", Generation(ns))
+	}
+	select {
+	case evt := <-sub:
+		if evt.Namespace != ns || evt.Generation != startGen+1 {
+			t.Fatalf("unexpected event: %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a ConfigChangeEvent for the initial apply")
+	}
+
+	// Re-applying the identical config must not bump the generation or
+	// publish another event.
+	applyReload(ns, cfg)
+	if Generation(ns) != startGen+1 {
+		t.Fatalf("expected generation to stay the same for an unchanged config, got %d", Generation(ns))
+	}
+	select {
+	case evt := <-sub:
+		t.Fatalf("did not expect an event for an unchanged config: %+v", evt)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Changing the config must bump the generation and publish again.
+	changed := DBConfigs{Redis: &RedisConfig{Host: "otherhost", Port: "6379"}}
+	applyReload(ns, changed)
+	if Generation(ns) != startGen+2 {
+		t.Fatalf("expected generation to advance again for a changed config, got %d", Generation(ns))
+	}
+	select {
+	case evt := <-sub:
+		if evt.Generation != startGen+2 {
+			t.Fatalf("unexpected generation in event: %d", evt.Generation)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a ConfigChangeEvent for the changed config")
+	}
+}
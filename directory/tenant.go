@@ -0,0 +1,295 @@
+package directory
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultTenantCacheTTL  = 5 * time.Minute
+	defaultTenantCacheSize = 1024
+)
+
+// TenantRegistry is the pluggable backend behind the SaaS tenant
+// resolution path. It is consulted by FetchNamespace to map a tenant
+// principal (email, or another claim pulled out of a JWT/API key
+// upstream) to a NamespaceID, and by GetDatabaseConfig to hydrate a
+// namespace's DBConfigs on a directory miss.
+type TenantRegistry interface {
+	// ResolveNamespace maps a tenant principal to the NamespaceID it
+	// belongs to. It returns ErrNamespaceNotFound if no tenant matches.
+	ResolveNamespace(ctx context.Context, principal string) (NamespaceID, error)
+	// LoadConfigs fetches the DBConfigs provisioned for an already-known
+	// namespace. It returns ErrNamespaceNotFound if the namespace is
+	// unknown to the registry.
+	LoadConfigs(ctx context.Context, ns NamespaceID) (DBConfigs, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   TenantRegistry
+)
+
+// SetTenantRegistry installs the backend used to resolve SaaS tenants.
+// It must be set before KENGINE_SAAS_MODE=on traffic depends on
+// FetchNamespace or lazy namespace hydration; until then both behave as
+// if no tenant were known.
+func SetTenantRegistry(r TenantRegistry) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = r
+}
+
+func currentRegistry() TenantRegistry {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry
+}
+
+// RegisterNamespace adds or replaces the DBConfigs for ns, making it
+// immediately visible to GetDatabaseConfig and ForEachNamespace without
+// waiting on a registry round-trip.
+func RegisterNamespace(ns NamespaceID, cfg DBConfigs) {
+	directory.Lock()
+	directory.Directory[ns] = cfg
+	directory.Unlock()
+	tenantCacheStore.set(ns, cfg)
+}
+
+// UnregisterNamespace removes ns from the directory and evicts any
+// cached lookup for it.
+func UnregisterNamespace(ns NamespaceID) {
+	directory.Lock()
+	delete(directory.Directory, ns)
+	directory.Unlock()
+	tenantCacheStore.invalidate(ns)
+}
+
+// RefreshNamespace discards the cached DBConfigs for ns, if any, and
+// re-hydrates it from the TenantRegistry. Use this after a tenant's
+// backends have been re-provisioned and the cached entry would
+// otherwise serve stale configs until it expires.
+func RefreshNamespace(ctx context.Context, ns NamespaceID) error {
+	tenantCacheStore.invalidate(ns)
+	_, err := resolveNamespace(ctx, ns)
+	return err
+}
+
+// resolveNamespace hydrates ns from the TenantRegistry, populating only
+// the TTL-bounded lookup cache, not the permanent directory map. A
+// namespace resolved this way must keep expiring and re-resolving on
+// every cache miss; writing it into directory.Directory would pin it
+// there forever (no TTL, no eviction) and make the cache inert after
+// the first lookup. Callers that want a namespace to live permanently
+// in the directory should use RegisterNamespace instead. Holds no locks
+// across the registry call.
+func resolveNamespace(ctx context.Context, ns NamespaceID) (DBConfigs, error) {
+	r := currentRegistry()
+	if r == nil {
+		return DBConfigs{}, ErrNamespaceNotFound
+	}
+	cfg, err := r.LoadConfigs(ctx, ns)
+	if err != nil {
+		return DBConfigs{}, err
+	}
+	tenantCacheStore.set(ns, cfg)
+	return cfg, nil
+}
+
+// InMemoryTenantRegistry is a TenantRegistry backed by a plain map. It
+// exists for tests and single-process development; production SaaS
+// deployments should use PostgresTenantRegistry.
+type InMemoryTenantRegistry struct {
+	mu      sync.RWMutex
+	byEmail map[string]NamespaceID
+	configs map[NamespaceID]DBConfigs
+}
+
+func NewInMemoryTenantRegistry() *InMemoryTenantRegistry {
+	return &InMemoryTenantRegistry{
+		byEmail: map[string]NamespaceID{},
+		configs: map[NamespaceID]DBConfigs{},
+	}
+}
+
+func (r *InMemoryTenantRegistry) ResolveNamespace(ctx context.Context, principal string) (NamespaceID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ns, found := r.byEmail[principal]
+	if !found {
+		return "", ErrNamespaceNotFound
+	}
+	return ns, nil
+}
+
+func (r *InMemoryTenantRegistry) LoadConfigs(ctx context.Context, ns NamespaceID) (DBConfigs, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg, found := r.configs[ns]
+	if !found {
+		return DBConfigs{}, ErrNamespaceNotFound
+	}
+	return cfg, nil
+}
+
+// Put seeds the registry with a tenant, associating principal with ns
+// and ns with cfg in a single call. Exposed for tests that need to
+// populate the registry directly rather than through Postgres.
+func (r *InMemoryTenantRegistry) Put(principal string, ns NamespaceID, cfg DBConfigs) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byEmail[principal] = ns
+	r.configs[ns] = cfg
+}
+
+// PostgresTenantRegistry resolves tenants from the `tenants` table:
+//
+//	tenants(email TEXT PRIMARY KEY, namespace TEXT NOT NULL, db_configs_json JSONB NOT NULL)
+//
+// db_configs_json holds a JSON-encoded DBConfigs for the tenant's
+// dedicated backends.
+type PostgresTenantRegistry struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresTenantRegistry(pool *pgxpool.Pool) *PostgresTenantRegistry {
+	return &PostgresTenantRegistry{pool: pool}
+}
+
+func (r *PostgresTenantRegistry) ResolveNamespace(ctx context.Context, principal string) (NamespaceID, error) {
+	var namespace string
+	err := r.pool.QueryRow(ctx,
+		`SELECT namespace FROM tenants WHERE email = $1`, principal,
+	).Scan(&namespace)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNamespaceNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("directory: resolve namespace for %q: %w", principal, err)
+	}
+	return NamespaceID(namespace), nil
+}
+
+func (r *PostgresTenantRegistry) LoadConfigs(ctx context.Context, ns NamespaceID) (DBConfigs, error) {
+	var rawConfigs []byte
+	err := r.pool.QueryRow(ctx,
+		`SELECT db_configs_json FROM tenants WHERE namespace = $1`, string(ns),
+	).Scan(&rawConfigs)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DBConfigs{}, ErrNamespaceNotFound
+	}
+	if err != nil {
+		return DBConfigs{}, fmt.Errorf("directory: load configs for namespace %q: %w", ns, err)
+	}
+	var cfg DBConfigs
+	if err := json.Unmarshal(rawConfigs, &cfg); err != nil {
+		return DBConfigs{}, fmt.Errorf("directory: decode db_configs_json for namespace %q: %w", ns, err)
+	}
+	return cfg, nil
+}
+
+// tenantCache is an LRU cache of NamespaceID -> DBConfigs with a fixed
+// TTL, sitting in front of the TenantRegistry so GetDatabaseConfig
+// doesn't hit the registry on every request for a known tenant.
+type tenantCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	entries map[NamespaceID]*tenantCacheEntry
+	order   *list.List // front = most recently used
+}
+
+type tenantCacheEntry struct {
+	cfg       DBConfigs
+	expiresAt time.Time
+	element   *list.Element
+}
+
+func newTenantCache(ttl time.Duration, maxSize int) *tenantCache {
+	return &tenantCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: map[NamespaceID]*tenantCacheEntry{},
+		order:   list.New(),
+	}
+}
+
+var tenantCacheStore = newTenantCache(defaultTenantCacheTTL, defaultTenantCacheSize)
+
+func (c *tenantCache) get(ns NamespaceID) (DBConfigs, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[ns]
+	if !found {
+		return DBConfigs{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		c.removeLocked(ns)
+		return DBConfigs{}, false
+	}
+	c.order.MoveToFront(entry.element)
+	return entry.cfg, true
+}
+
+func (c *tenantCache) set(ns NamespaceID, cfg DBConfigs) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, found := c.entries[ns]; found {
+		entry.cfg = cfg
+		entry.expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(entry.element)
+		return
+	}
+	entry := &tenantCacheEntry{cfg: cfg, expiresAt: time.Now().Add(c.ttl)}
+	entry.element = c.order.PushFront(ns)
+	c.entries[ns] = entry
+	for len(c.entries) > c.maxSize {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeLocked(oldest.Value.(NamespaceID))
+	}
+}
+
+// snapshot returns the DBConfigs of every entry that hasn't expired yet,
+// without touching recency order. Used by HealthCheck to sweep SaaS
+// tenant namespaces resolved lazily through the cache rather than
+// RegisterNamespace, which otherwise wouldn't show up in
+// directory.Directory at all.
+func (c *tenantCache) snapshot() map[NamespaceID]DBConfigs {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	out := make(map[NamespaceID]DBConfigs, len(c.entries))
+	for ns, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		out[ns] = entry.cfg
+	}
+	return out
+}
+
+func (c *tenantCache) invalidate(ns NamespaceID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(ns)
+}
+
+func (c *tenantCache) removeLocked(ns NamespaceID) {
+	entry, found := c.entries[ns]
+	if !found {
+		return
+	}
+	c.order.Remove(entry.element)
+	delete(c.entries, ns)
+}
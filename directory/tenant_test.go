@@ -0,0 +1,91 @@
+package directory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTenantCacheExpiresAndEvictsLRU(t *testing.T) {
+	c := newTenantCache(10*time.Millisecond, 2)
+
+	c.set("a", DBConfigs{})
+	c.set("b", DBConfigs{})
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+
+	// a was just touched by the get above, so it's more recently used
+	// than b; adding a third entry over capacity should evict b.
+	c.set("c", DBConfigs{})
+	if _, ok := c.get("b"); ok {
+		t.Fatal("expected b to have been evicted as least recently used")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("expected a to survive eviction since it was touched more recently")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.get("a"); ok {
+		t.Fatal("expected a to have expired")
+	}
+}
+
+// countingRegistry wraps a TenantRegistry and counts LoadConfigs calls,
+// so tests can assert how often the registry was actually consulted.
+type countingRegistry struct {
+	TenantRegistry
+	calls *int
+}
+
+func (r countingRegistry) LoadConfigs(ctx context.Context, ns NamespaceID) (DBConfigs, error) {
+	*r.calls = *r.calls + 1
+	return r.TenantRegistry.LoadConfigs(ctx, ns)
+}
+
+// TestGetDatabaseConfigRehydratesAfterCacheExpiry guards against a
+// namespace resolved via the TenantRegistry getting pinned permanently
+// into the directory: once tenantCacheStore's TTL lapses, the next
+// lookup must go back to the registry rather than serving the same
+// cached entry forever.
+func TestGetDatabaseConfigRehydratesAfterCacheExpiry(t *testing.T) {
+	const ns = NamespaceID("tenant-1")
+
+	prevCache := tenantCacheStore
+	prevRegistry := currentRegistry()
+	tenantCacheStore = newTenantCache(10*time.Millisecond, 16)
+	t.Cleanup(func() {
+		tenantCacheStore = prevCache
+		SetTenantRegistry(prevRegistry)
+	})
+
+	backing := NewInMemoryTenantRegistry()
+	backing.Put("tenant@example.com", ns, DBConfigs{})
+	calls := 0
+	SetTenantRegistry(countingRegistry{backing, &calls})
+
+	ctx := NewContextWithNameSpace(ns)
+
+	if _, err := GetDatabaseConfig(ctx); err != nil {
+		t.Fatalf("first lookup: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the registry to be consulted once, got %d calls", calls)
+	}
+
+	if _, err := GetDatabaseConfig(ctx); err != nil {
+		t.Fatalf("second lookup: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the second lookup to be served from cache, registry called %d times", calls)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := GetDatabaseConfig(ctx); err != nil {
+		t.Fatalf("third lookup: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the registry to be consulted again after cache expiry, got %d calls", calls)
+	}
+}